@@ -2,19 +2,35 @@ package accountdatabase
 
 import (
     "context"
+    "errors"
     "fmt"
+    "strconv"
     "time"
+    "github.com/jackc/pgx/v4"
     "github.com/jackc/pgx/v4/pgxpool"
     "golang.org/x/crypto/bcrypt"
+
+    "github.com/Level-Up-Miami/level-up-miami/api/database/db"
+    "github.com/Level-Up-Miami/level-up-miami/api/database/migrations"
+    "github.com/Level-Up-Miami/level-up-miami/api/session"
 )
 
+const defaultSessionTTL = 24 * time.Hour
+
 // AccountDatabase represents the database for managing accounts
 type AccountDatabase struct {
-    Pool *pgxpool.Pool
+    Pool     *pgxpool.Pool
+    opts     Options
+    sessions *session.Manager
+    queries  *db.Queries
 }
 
 // NewAccountDatabase initializes a new AccountDatabase instance
-func NewAccountDatabase(dbURL string) (*AccountDatabase, error) {
+func NewAccountDatabase(dbURL string, opts Options) (*AccountDatabase, error) {
+    if len(opts.JWTSecret) == 0 {
+        return nil, fmt.Errorf("JWTSecret must be set: sessions would otherwise be signed with an empty HMAC key")
+    }
+
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
@@ -28,41 +44,26 @@ func NewAccountDatabase(dbURL string) (*AccountDatabase, error) {
         return nil, fmt.Errorf("failed to initialize account database tables: %w", err)
     }
 
-    return &AccountDatabase{Pool: pool}, nil
+    return &AccountDatabase{
+        Pool:     pool,
+        opts:     opts,
+        sessions: session.NewManager(opts.JWTSecret),
+        queries:  db.New(pool),
+    }, nil
 }
 
-// InitializeAccountDatabase initializes the account-related tables
+// InitializeAccountDatabase brings the account-related schema up to date by
+// applying any pending migrations, then bootstraps the admin account.
 func InitializeAccountDatabase(pool *pgxpool.Pool) error {
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
-    queries := []string{
-        `
-        CREATE TABLE IF NOT EXISTS accountsettings (
-            account_id SERIAL PRIMARY KEY,
-            username TEXT NOT NULL UNIQUE,
-            email TEXT NOT NULL UNIQUE,
-            password TEXT NOT NULL,
-            email_verified BOOLEAN DEFAULT FALSE
-        );
-        `,
-        `
-        CREATE TABLE IF NOT EXISTS transaction_history (
-            transaction_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-            client_id TEXT NOT NULL,
-            transaction_type TEXT NOT NULL,
-            items_sent JSONB,
-            items_received JSONB,
-            notes TEXT,
-            status TEXT DEFAULT 'Pending...'
-        );
-        `,
+    if err := migrations.Migrate(ctx, pool); err != nil {
+        return fmt.Errorf("failed to apply migrations: %w", err)
     }
 
-    for _, q := range queries {
-        if _, err := pool.Exec(ctx, q); err != nil {
-            return fmt.Errorf("failed to execute query: %w", err)
-        }
+    if err := bootstrapAdmin(ctx, pool); err != nil {
+        return fmt.Errorf("failed to bootstrap admin account: %w", err)
     }
 
     return nil
@@ -78,12 +79,7 @@ func (db *AccountDatabase) CreateUser(username, password, email string) error {
         return fmt.Errorf("failed to hash password: %w", err)
     }
 
-    query := `
-        INSERT INTO accountsettings (username, password, email)
-        VALUES ($1, $2, $3)
-    `
-    _, err = db.Pool.Exec(ctx, query, username, string(hashedPassword), email)
-    if err != nil {
+    if err := db.queries.CreateUser(ctx, username, string(hashedPassword), email); err != nil {
         return fmt.Errorf("failed to create user: %w", err)
     }
 
@@ -95,86 +91,93 @@ func (db *AccountDatabase) GetUserByUsername(username string) (*User, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
-    var user User
-    err := db.Pool.QueryRow(ctx, `
-        SELECT account_id, username, email, password, email_verified
-        FROM accountsettings
-        WHERE username = $1
-    `, username).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.EmailVerified)
+    row, err := db.queries.GetUserByUsername(ctx, username)
     if err != nil {
         return nil, fmt.Errorf("failed to get user by username: %w", err)
     }
 
-    return &user, nil
+    return accountsettingToUser(row), nil
 }
 
-// ValidateCredentials checks if the provided username and password are correct
-func (db *AccountDatabase) ValidateCredentials(username, password string) (bool, bool, error) {
+// accountsettingToUser adapts a generated db.Accountsetting row to the
+// package's public User type.
+func accountsettingToUser(row db.Accountsetting) *User {
+    return &User{
+        ID:            int(row.AccountID),
+        Username:      row.Username,
+        Email:         row.Email,
+        Password:      row.Password,
+        EmailVerified: row.EmailVerified,
+        Role:          row.Role,
+    }
+}
+
+// ValidateCredentials checks if the provided username and password are
+// correct. The third return value, otpRequired, tells the caller whether a
+// confirmed TOTP enrollment exists and a second factor must be prompted for
+// before the session is considered fully authenticated.
+func (db *AccountDatabase) ValidateCredentials(username, password string) (bool, bool, bool, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
-    var storedPassword string
-    var emailVerified bool
-    query := `SELECT password, email_verified FROM accountsettings WHERE username=$1`
-    err := db.Pool.QueryRow(ctx, query, username).Scan(&storedPassword, &emailVerified)
+    creds, err := db.queries.GetCredentialsByUsername(ctx, username)
     if err != nil {
-        if err.Error() == "no rows in result set" {
-            return false, false, nil // Username not found
+        if errors.Is(err, pgx.ErrNoRows) {
+            return false, false, false, nil // Username not found
         }
-        return false, false, fmt.Errorf("failed to query accountsettings: %w", err)
+        return false, false, false, fmt.Errorf("failed to query accountsettings: %w", err)
     }
 
     // Check if the password matches
-    if err := bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(password)); err != nil {
-        return false, emailVerified, nil
+    if err := bcrypt.CompareHashAndPassword([]byte(creds.Password), []byte(password)); err != nil {
+        return false, creds.EmailVerified, false, nil
+    }
+
+    otpRequired, err := db.queries.GetOTPConfirmedByUsername(ctx, username)
+    if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+        return false, false, false, fmt.Errorf("failed to query user_otp: %w", err)
     }
 
-    return true, emailVerified, nil
+    return true, creds.EmailVerified, otpRequired, nil
 }
 
-// VerifyUserEmail sets the email_verified flag to true for a given username
-func (db *AccountDatabase) VerifyUserEmail(username string) error {
+// VerifyUserEmail sets the email_verified flag to true for the authenticated
+// user. Taking *User (rather than a raw username) ensures a caller can only
+// verify the email of the account it authenticated as.
+func (db *AccountDatabase) VerifyUserEmail(user *User) error {
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
-    query := `UPDATE accountsettings SET email_verified = TRUE WHERE username = $1`
-    _, err := db.Pool.Exec(ctx, query, username)
-    if err != nil {
+    if err := db.queries.VerifyUserEmail(ctx, int32(user.ID)); err != nil {
         return fmt.Errorf("failed to verify email: %w", err)
     }
 
     return nil
 }
 
-// UpdateAccount updates account details in the accountsettings table
-func (db *AccountDatabase) UpdateAccount(username, newUsername, newEmail string) error {
+// UpdateAccount updates account details for the authenticated user. Taking
+// *User (rather than a raw username) ensures a caller can only mutate the
+// account it authenticated as.
+func (db *AccountDatabase) UpdateAccount(user *User, newUsername, newEmail string) error {
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
-    _, err := db.Pool.Exec(ctx, `
-        UPDATE accountsettings
-        SET username = COALESCE($1, username), email = COALESCE($2, email)
-        WHERE username = $3
-    `, newUsername, newEmail, username)
-
-    if err != nil {
+    if err := db.queries.UpdateAccount(ctx, newUsername, newEmail, int32(user.ID)); err != nil {
         return fmt.Errorf("failed to update account: %w", err)
     }
 
     return nil
 }
 
-// AddTransaction adds a new transaction to the transaction_history table
-func (db *AccountDatabase) AddTransaction(clientID, transactionType, itemsSent, itemsReceived, notes string) error {
+// AddTransaction adds a new transaction to the transaction_history table on
+// behalf of the authenticated user. Taking *User (rather than a raw client
+// ID) ensures a caller can only record transactions for the account it
+// authenticated as.
+func (db *AccountDatabase) AddTransaction(user *User, transactionType, itemsSent, itemsReceived, notes string) error {
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
     defer cancel()
 
-    query := `
-        INSERT INTO transaction_history (client_id, transaction_type, items_sent, items_received, notes, status)
-        VALUES ($1, $2, $3, $4, $5, 'Pending...')
-    `
-    _, err := db.Pool.Exec(ctx, query, clientID, transactionType, itemsSent, itemsReceived, notes)
-    if err != nil {
+    if err := db.queries.AddTransaction(ctx, strconv.Itoa(user.ID), transactionType, itemsSent, itemsReceived, notes); err != nil {
         return fmt.Errorf("failed to add transaction: %w", err)
     }
 
@@ -188,4 +191,5 @@ type User struct {
     Email         string
     Password      string
     EmailVerified bool
+    Role          string
 }
\ No newline at end of file
@@ -0,0 +1,344 @@
+package accountdatabase
+
+import (
+    "context"
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha1"
+    "encoding/base32"
+    "encoding/binary"
+    "encoding/json"
+    "fmt"
+    "math"
+    "net/url"
+    "strings"
+    "time"
+
+    "golang.org/x/crypto/bcrypt"
+)
+
+const (
+    otpIssuer       = "LevelUpMiami"
+    otpDigits       = 6
+    otpPeriod       = 30
+    otpSecretLength = 20
+    otpBackupCodes  = 10
+    otpStepWindow   = 1
+)
+
+// Options configures an AccountDatabase instance.
+type Options struct {
+    // OTPEncryptionKey is a 32-byte AES-256 key used to encrypt TOTP secrets
+    // at rest. Required if OTP enrollment is used.
+    OTPEncryptionKey []byte
+
+    // JWTSecret signs and verifies session tokens issued by IssueSession.
+    JWTSecret []byte
+
+    // SessionTTL controls how long an issued session token remains valid.
+    // Defaults to 24 hours if zero.
+    SessionTTL time.Duration
+
+    // PasswordResetSecret signs password reset tokens issued by
+    // RequestPasswordReset.
+    PasswordResetSecret []byte
+}
+
+// EnrollOTP begins TOTP enrollment for a user, generating a new secret and
+// returning a provisioning URI the caller can render as a QR code. The
+// enrollment is not active until ConfirmOTP succeeds.
+func (db *AccountDatabase) EnrollOTP(username string) (string, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    user, err := db.GetUserByUsername(username)
+    if err != nil {
+        return "", fmt.Errorf("failed to look up user for otp enrollment: %w", err)
+    }
+
+    secret := make([]byte, otpSecretLength)
+    if _, err := rand.Read(secret); err != nil {
+        return "", fmt.Errorf("failed to generate otp secret: %w", err)
+    }
+
+    encrypted, err := db.encryptOTPSecret(secret)
+    if err != nil {
+        return "", fmt.Errorf("failed to encrypt otp secret: %w", err)
+    }
+
+    _, err = db.Pool.Exec(ctx, `
+        INSERT INTO user_otp (account_id, secret, digits, period, confirmed, backup_codes)
+        VALUES ($1, $2, $3, $4, FALSE, '[]')
+        ON CONFLICT (account_id) DO UPDATE
+        SET secret = EXCLUDED.secret, digits = EXCLUDED.digits, period = EXCLUDED.period,
+            confirmed = FALSE, backup_codes = '[]', last_counter = NULL
+    `, user.ID, encrypted, otpDigits, otpPeriod)
+    if err != nil {
+        return "", fmt.Errorf("failed to store otp enrollment: %w", err)
+    }
+
+    return otpProvisioningURI(username, secret), nil
+}
+
+// ConfirmOTP validates the first code from a freshly enrolled authenticator
+// and, on success, marks the enrollment confirmed and returns a set of
+// single-use backup codes.
+func (db *AccountDatabase) ConfirmOTP(username, code string) ([]string, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    accountID, secret, _, confirmed, err := db.getOTPEnrollment(ctx, username)
+    if err != nil {
+        return nil, err
+    }
+    if confirmed {
+        return nil, fmt.Errorf("otp already confirmed for user %q", username)
+    }
+
+    counter, ok := verifyTOTP(secret, code, time.Now())
+    if !ok {
+        return nil, fmt.Errorf("invalid otp code")
+    }
+
+    backupCodes := make([]string, otpBackupCodes)
+    hashedCodes := make([]string, otpBackupCodes)
+    for i := 0; i < otpBackupCodes; i++ {
+        plain, err := generateBackupCode()
+        if err != nil {
+            return nil, fmt.Errorf("failed to generate backup code: %w", err)
+        }
+        hashed, err := bcrypt.GenerateFromPassword([]byte(plain), bcrypt.DefaultCost)
+        if err != nil {
+            return nil, fmt.Errorf("failed to hash backup code: %w", err)
+        }
+        backupCodes[i] = plain
+        hashedCodes[i] = string(hashed)
+    }
+
+    backupJSON, err := json.Marshal(hashedCodes)
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode backup codes: %w", err)
+    }
+
+    _, err = db.Pool.Exec(ctx, `
+        UPDATE user_otp
+        SET confirmed = TRUE, backup_codes = $2, last_counter = $3
+        WHERE account_id = $1
+    `, accountID, backupJSON, counter)
+    if err != nil {
+        return nil, fmt.Errorf("failed to confirm otp enrollment: %w", err)
+    }
+
+    return backupCodes, nil
+}
+
+// VerifyOTP checks a TOTP code for a confirmed enrollment, rejecting reuse
+// of a code already accepted within the current window.
+func (db *AccountDatabase) VerifyOTP(username, code string) (bool, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    accountID, secret, lastCounter, confirmed, err := db.getOTPEnrollment(ctx, username)
+    if err != nil {
+        return false, err
+    }
+    if !confirmed {
+        return false, fmt.Errorf("otp is not confirmed for user %q", username)
+    }
+
+    counter, ok := verifyTOTP(secret, code, time.Now())
+    if !ok {
+        return false, nil
+    }
+    if lastCounter != nil && counter <= *lastCounter {
+        return false, nil // replay of an already-accepted step
+    }
+
+    // Atomically claim this counter: the WHERE clause re-checks the replay
+    // condition at write time, so two concurrent VerifyOTP calls for the
+    // same code can't both pass the earlier in-Go comparison and both win.
+    tag, err := db.Pool.Exec(ctx, `
+        UPDATE user_otp
+        SET last_counter = $2
+        WHERE account_id = $1 AND (last_counter IS NULL OR last_counter < $2)
+    `, accountID, counter)
+    if err != nil {
+        return false, fmt.Errorf("failed to record otp counter: %w", err)
+    }
+    if tag.RowsAffected() == 0 {
+        return false, nil // lost the race to a concurrent verification of the same step
+    }
+
+    return true, nil
+}
+
+// ConsumeBackupCode redeems a single-use backup code in place of a TOTP
+// code, invalidating it so it cannot be used again.
+func (db *AccountDatabase) ConsumeBackupCode(username, code string) (bool, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    var accountID int
+    var confirmed bool
+    var backupJSON []byte
+    err := db.Pool.QueryRow(ctx, `
+        SELECT account_id, confirmed, backup_codes FROM user_otp WHERE account_id = (
+            SELECT account_id FROM accountsettings WHERE username = $1
+        )
+    `, username).Scan(&accountID, &confirmed, &backupJSON)
+    if err != nil {
+        return false, fmt.Errorf("failed to look up otp enrollment: %w", err)
+    }
+    if !confirmed {
+        return false, fmt.Errorf("otp is not confirmed for user %q", username)
+    }
+
+    var hashedCodes []string
+    if err := json.Unmarshal(backupJSON, &hashedCodes); err != nil {
+        return false, fmt.Errorf("failed to decode backup codes: %w", err)
+    }
+
+    for i, hashed := range hashedCodes {
+        if bcrypt.CompareHashAndPassword([]byte(hashed), []byte(code)) == nil {
+            hashedCodes = append(hashedCodes[:i], hashedCodes[i+1:]...)
+            remaining, err := json.Marshal(hashedCodes)
+            if err != nil {
+                return false, fmt.Errorf("failed to encode backup codes: %w", err)
+            }
+            _, err = db.Pool.Exec(ctx, `UPDATE user_otp SET backup_codes = $2 WHERE account_id = $1`, accountID, remaining)
+            if err != nil {
+                return false, fmt.Errorf("failed to consume backup code: %w", err)
+            }
+            return true, nil
+        }
+    }
+
+    return false, nil
+}
+
+// DisableOTP removes a user's TOTP enrollment entirely.
+func (db *AccountDatabase) DisableOTP(username string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    _, err := db.Pool.Exec(ctx, `
+        DELETE FROM user_otp WHERE account_id = (SELECT account_id FROM accountsettings WHERE username = $1)
+    `, username)
+    if err != nil {
+        return fmt.Errorf("failed to disable otp: %w", err)
+    }
+
+    return nil
+}
+
+// getOTPEnrollment loads and decrypts the OTP enrollment for username.
+func (db *AccountDatabase) getOTPEnrollment(ctx context.Context, username string) (accountID int, secret []byte, lastCounter *uint64, confirmed bool, err error) {
+    var encrypted []byte
+    err = db.Pool.QueryRow(ctx, `
+        SELECT o.account_id, o.secret, o.last_counter, o.confirmed
+        FROM user_otp o
+        JOIN accountsettings a ON a.account_id = o.account_id
+        WHERE a.username = $1
+    `, username).Scan(&accountID, &encrypted, &lastCounter, &confirmed)
+    if err != nil {
+        return 0, nil, nil, false, fmt.Errorf("failed to load otp enrollment: %w", err)
+    }
+
+    secret, err = db.decryptOTPSecret(encrypted)
+    if err != nil {
+        return 0, nil, nil, false, fmt.Errorf("failed to decrypt otp secret: %w", err)
+    }
+
+    return accountID, secret, lastCounter, confirmed, nil
+}
+
+func (db *AccountDatabase) encryptOTPSecret(secret []byte) ([]byte, error) {
+    block, err := aes.NewCipher(db.opts.OTPEncryptionKey)
+    if err != nil {
+        return nil, err
+    }
+    gcm, err := cipher.NewGCM(block)
+    if err != nil {
+        return nil, err
+    }
+    nonce := make([]byte, gcm.NonceSize())
+    if _, err := rand.Read(nonce); err != nil {
+        return nil, err
+    }
+    return gcm.Seal(nonce, nonce, secret, nil), nil
+}
+
+func (db *AccountDatabase) decryptOTPSecret(ciphertext []byte) ([]byte, error) {
+    block, err := aes.NewCipher(db.opts.OTPEncryptionKey)
+    if err != nil {
+        return nil, err
+    }
+    gcm, err := cipher.NewGCM(block)
+    if err != nil {
+        return nil, err
+    }
+    nonceSize := gcm.NonceSize()
+    if len(ciphertext) < nonceSize {
+        return nil, fmt.Errorf("otp secret ciphertext too short")
+    }
+    nonce, sealed := ciphertext[:nonceSize], ciphertext[nonceSize:]
+    return gcm.Open(nil, nonce, sealed, nil)
+}
+
+// otpProvisioningURI builds an otpauth:// URI suitable for rendering as a
+// QR code in an authenticator app.
+func otpProvisioningURI(username string, secret []byte) string {
+    encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret)
+    label := fmt.Sprintf("%s:%s", otpIssuer, username)
+    return fmt.Sprintf("otpauth://totp/%s?secret=%s&issuer=%s&digits=%d&period=%d",
+        url.PathEscape(label), encoded, url.QueryEscape(otpIssuer), otpDigits, otpPeriod)
+}
+
+// verifyTOTP checks code against secret for the step containing t, allowing
+// a +/-1 step window for clock skew, and returns the matched counter.
+func verifyTOTP(secret []byte, code string, t time.Time) (uint64, bool) {
+    counter := uint64(t.Unix()) / otpPeriod
+    for _, delta := range []int64{0, -otpStepWindow, otpStepWindow} {
+        step := int64(counter) + delta
+        if step < 0 {
+            continue
+        }
+        if generateTOTP(secret, uint64(step)) == code {
+            return uint64(step), true
+        }
+    }
+    return 0, false
+}
+
+// generateTOTP computes the RFC 6238 TOTP value for the given counter.
+func generateTOTP(secret []byte, counter uint64) string {
+    buf := make([]byte, 8)
+    binary.BigEndian.PutUint64(buf, counter)
+
+    mac := hmac.New(sha1.New, secret)
+    mac.Write(buf)
+    sum := mac.Sum(nil)
+
+    offset := sum[len(sum)-1] & 0x0f
+    truncated := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+    code := truncated % uint32(math.Pow10(otpDigits))
+
+    return fmt.Sprintf("%0*d", otpDigits, code)
+}
+
+// generateBackupCode returns a random 10-character uppercase alphanumeric
+// single-use backup code.
+func generateBackupCode() (string, error) {
+    const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+    buf := make([]byte, 10)
+    if _, err := rand.Read(buf); err != nil {
+        return "", err
+    }
+    var sb strings.Builder
+    for _, b := range buf {
+        sb.WriteByte(alphabet[int(b)%len(alphabet)])
+    }
+    return sb.String(), nil
+}
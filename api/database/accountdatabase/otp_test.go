@@ -0,0 +1,69 @@
+package accountdatabase
+
+import (
+    "testing"
+    "time"
+)
+
+func TestVerifyTOTPAcceptsCurrentStep(t *testing.T) {
+    secret := []byte("super-secret-otp-key")
+    now := time.Unix(1_700_000_000, 0)
+    counter := uint64(now.Unix()) / otpPeriod
+    code := generateTOTP(secret, counter)
+
+    got, ok := verifyTOTP(secret, code, now)
+    if !ok {
+        t.Fatalf("verifyTOTP(%q) = false, want true", code)
+    }
+    if got != counter {
+        t.Errorf("verifyTOTP counter = %d, want %d", got, counter)
+    }
+}
+
+func TestVerifyTOTPAcceptsAdjacentStepsWithinWindow(t *testing.T) {
+    secret := []byte("super-secret-otp-key")
+    now := time.Unix(1_700_000_000, 0)
+    counter := uint64(now.Unix()) / otpPeriod
+
+    for _, delta := range []int64{-otpStepWindow, otpStepWindow} {
+        code := generateTOTP(secret, uint64(int64(counter)+delta))
+        if _, ok := verifyTOTP(secret, code, now); !ok {
+            t.Errorf("verifyTOTP did not accept step at delta %d", delta)
+        }
+    }
+}
+
+func TestVerifyTOTPRejectsStepsOutsideWindow(t *testing.T) {
+    secret := []byte("super-secret-otp-key")
+    now := time.Unix(1_700_000_000, 0)
+    counter := uint64(now.Unix()) / otpPeriod
+
+    code := generateTOTP(secret, counter+otpStepWindow+1)
+    if _, ok := verifyTOTP(secret, code, now); ok {
+        t.Errorf("verifyTOTP accepted a step outside the +/- window")
+    }
+}
+
+func TestVerifyTOTPRejectsWrongCode(t *testing.T) {
+    secret := []byte("super-secret-otp-key")
+    now := time.Unix(1_700_000_000, 0)
+
+    if _, ok := verifyTOTP(secret, "000000", now); ok {
+        t.Errorf("verifyTOTP accepted an arbitrary wrong code")
+    }
+}
+
+func TestGenerateTOTPIsDeterministicAndSixDigits(t *testing.T) {
+    secret := []byte("super-secret-otp-key")
+
+    code := generateTOTP(secret, 42)
+    if len(code) != otpDigits {
+        t.Fatalf("generateTOTP returned %q, want %d digits", code, otpDigits)
+    }
+    if again := generateTOTP(secret, 42); again != code {
+        t.Errorf("generateTOTP(42) = %q, then %q; want deterministic", code, again)
+    }
+    if different := generateTOTP(secret, 43); different == code {
+        t.Errorf("generateTOTP(43) produced the same code as generateTOTP(42)")
+    }
+}
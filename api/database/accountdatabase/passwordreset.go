@@ -0,0 +1,195 @@
+package accountdatabase
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/jackc/pgx/v4"
+    "golang.org/x/crypto/bcrypt"
+)
+
+// passwordResetTTL is how long a password reset token remains valid.
+const passwordResetTTL = 30 * time.Minute
+
+// RequestPasswordReset issues a signed, time-limited token for email,
+// following the dchest/passwordreset pattern: the signing key is the
+// server secret concatenated with the account's *current* bcrypt password
+// hash, so a successful password change automatically invalidates every
+// outstanding token without needing to delete rows. The returned token is
+// a URL-safe string safe to embed in an email link and is never logged.
+//
+// If email does not match any account, RequestPasswordReset returns a nil
+// error and an empty token rather than propagating a not-found error: the
+// caller must present the same outward response either way, since an
+// error distinguishable from success would let a caller enumerate which
+// emails have accounts.
+func (db *AccountDatabase) RequestPasswordReset(email string) (string, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    var accountID int
+    var passwordHash string
+    err := db.Pool.QueryRow(ctx, `
+        SELECT account_id, password FROM accountsettings WHERE email = $1
+    `, email).Scan(&accountID, &passwordHash)
+    if err != nil {
+        if errors.Is(err, pgx.ErrNoRows) {
+            return "", nil // no account for email; don't reveal that to the caller
+        }
+        return "", fmt.Errorf("failed to look up account by email: %w", err)
+    }
+
+    expiresAt := time.Now().Add(passwordResetTTL)
+    token := signResetToken(db.opts.PasswordResetSecret, passwordHash, accountID, expiresAt)
+
+    _, err = db.Pool.Exec(ctx, `
+        INSERT INTO password_reset_tokens (token_hash, account_id, expires_at)
+        VALUES ($1, $2, $3)
+    `, hashResetToken(token), accountID, expiresAt)
+    if err != nil {
+        return "", fmt.Errorf("failed to record password reset token: %w", err)
+    }
+
+    return token, nil
+}
+
+// ResetPassword validates a token issued by RequestPasswordReset and, if it
+// is unexpired, unused, and still matches the account's current password
+// hash, sets the account's password to newPassword.
+func (db *AccountDatabase) ResetPassword(token, newPassword string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    accountID, expiresAt, err := parseResetToken(token)
+    if err != nil {
+        return fmt.Errorf("invalid reset token: %w", err)
+    }
+    if time.Now().After(expiresAt) {
+        return fmt.Errorf("reset token has expired")
+    }
+
+    var passwordHash string
+    err = db.Pool.QueryRow(ctx, `SELECT password FROM accountsettings WHERE account_id = $1`, accountID).Scan(&passwordHash)
+    if err != nil {
+        return fmt.Errorf("failed to look up account: %w", err)
+    }
+
+    expected := signResetToken(db.opts.PasswordResetSecret, passwordHash, accountID, expiresAt)
+    if !hmac.Equal([]byte(token), []byte(expected)) {
+        return fmt.Errorf("invalid reset token")
+    }
+
+    // Atomically claim the token: only one concurrent ResetPassword call can
+    // flip used_at from NULL, so this also serves as the single-use check.
+    tokenHash := hashResetToken(token)
+    tag, err := db.Pool.Exec(ctx, `
+        UPDATE password_reset_tokens
+        SET used_at = now()
+        WHERE token_hash = $1 AND account_id = $2 AND used_at IS NULL
+    `, tokenHash, accountID)
+    if err != nil {
+        return fmt.Errorf("failed to claim reset token: %w", err)
+    }
+    if tag.RowsAffected() == 0 {
+        return fmt.Errorf("reset token not found or already used")
+    }
+
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+    if err != nil {
+        return fmt.Errorf("failed to hash new password: %w", err)
+    }
+
+    _, err = db.Pool.Exec(ctx, `UPDATE accountsettings SET password = $1 WHERE account_id = $2`, string(hashedPassword), accountID)
+    if err != nil {
+        return fmt.Errorf("failed to update password: %w", err)
+    }
+
+    return nil
+}
+
+// ChangePassword re-verifies oldPassword via bcrypt before setting
+// username's password to newPassword.
+func (db *AccountDatabase) ChangePassword(username, oldPassword, newPassword string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    user, err := db.GetUserByUsername(username)
+    if err != nil {
+        return fmt.Errorf("failed to look up user: %w", err)
+    }
+
+    if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword)); err != nil {
+        return fmt.Errorf("old password is incorrect")
+    }
+
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+    if err != nil {
+        return fmt.Errorf("failed to hash new password: %w", err)
+    }
+
+    _, err = db.Pool.Exec(ctx, `UPDATE accountsettings SET password = $1 WHERE account_id = $2`, string(hashedPassword), user.ID)
+    if err != nil {
+        return fmt.Errorf("failed to update password: %w", err)
+    }
+
+    return nil
+}
+
+// signResetToken derives a signing key from serverSecret and the account's
+// current password hash, then signs "accountID:expiresAt" with it. Because
+// the key depends on the password hash, changing the password changes the
+// key and invalidates every token signed against the old one.
+func signResetToken(serverSecret []byte, passwordHash string, accountID int, expiresAt time.Time) string {
+    payload := fmt.Sprintf("%d:%d", accountID, expiresAt.Unix())
+
+    key := make([]byte, 0, len(serverSecret)+len(passwordHash))
+    key = append(key, serverSecret...)
+    key = append(key, passwordHash...)
+
+    mac := hmac.New(sha256.New, key)
+    mac.Write([]byte(payload))
+    sig := mac.Sum(nil)
+
+    raw := payload + ":" + hex.EncodeToString(sig)
+    return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// parseResetToken extracts the account id and expiry from a token without
+// verifying its signature; callers must still compare it against
+// signResetToken before trusting it.
+func parseResetToken(token string) (accountID int, expiresAt time.Time, err error) {
+    raw, err := base64.RawURLEncoding.DecodeString(token)
+    if err != nil {
+        return 0, time.Time{}, fmt.Errorf("malformed token encoding: %w", err)
+    }
+
+    parts := strings.SplitN(string(raw), ":", 3)
+    if len(parts) != 3 {
+        return 0, time.Time{}, fmt.Errorf("malformed token payload")
+    }
+
+    accountID, err = strconv.Atoi(parts[0])
+    if err != nil {
+        return 0, time.Time{}, fmt.Errorf("malformed account id: %w", err)
+    }
+
+    expiresUnix, err := strconv.ParseInt(parts[1], 10, 64)
+    if err != nil {
+        return 0, time.Time{}, fmt.Errorf("malformed expiry: %w", err)
+    }
+
+    return accountID, time.Unix(expiresUnix, 0), nil
+}
+
+func hashResetToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,48 @@
+package accountdatabase
+
+import (
+    "testing"
+    "time"
+)
+
+func TestSignAndParseResetTokenRoundTrip(t *testing.T) {
+    serverSecret := []byte("server-secret")
+    passwordHash := "$2a$10$fakebcrypthashforaccount"
+    accountID := 42
+    expiresAt := time.Unix(1_700_000_000, 0)
+
+    token := signResetToken(serverSecret, passwordHash, accountID, expiresAt)
+
+    gotID, gotExpiresAt, err := parseResetToken(token)
+    if err != nil {
+        t.Fatalf("parseResetToken returned error: %v", err)
+    }
+    if gotID != accountID {
+        t.Errorf("parseResetToken accountID = %d, want %d", gotID, accountID)
+    }
+    if !gotExpiresAt.Equal(expiresAt) {
+        t.Errorf("parseResetToken expiresAt = %v, want %v", gotExpiresAt, expiresAt)
+    }
+}
+
+func TestSignResetTokenChangesWithPasswordHash(t *testing.T) {
+    serverSecret := []byte("server-secret")
+    accountID := 42
+    expiresAt := time.Unix(1_700_000_000, 0)
+
+    before := signResetToken(serverSecret, "$2a$10$oldhash", accountID, expiresAt)
+    after := signResetToken(serverSecret, "$2a$10$newhash", accountID, expiresAt)
+
+    if before == after {
+        t.Errorf("signResetToken produced the same token after the password hash changed")
+    }
+}
+
+func TestParseResetTokenRejectsMalformedInput(t *testing.T) {
+    if _, _, err := parseResetToken("not-valid-base64!!"); err == nil {
+        t.Errorf("parseResetToken accepted malformed base64")
+    }
+    if _, _, err := parseResetToken(""); err == nil {
+        t.Errorf("parseResetToken accepted an empty token")
+    }
+}
@@ -0,0 +1,151 @@
+package accountdatabase
+
+import (
+    "context"
+    "fmt"
+    "os"
+    "time"
+
+    "github.com/jackc/pgx/v4/pgxpool"
+    "golang.org/x/crypto/bcrypt"
+)
+
+// RoleAdmin is the role granted to the bootstrap admin account and required
+// to call SetUserRole.
+const RoleAdmin = "admin"
+
+// bootstrapAdmin creates the initial admin account from ADMIN_USERNAME and
+// ADMIN_API_KEY if no admin exists yet. It is a no-op when the environment
+// variables are unset or an admin already exists.
+func bootstrapAdmin(ctx context.Context, pool *pgxpool.Pool) error {
+    username := os.Getenv("ADMIN_USERNAME")
+    apiKey := os.Getenv("ADMIN_API_KEY")
+    if username == "" || apiKey == "" {
+        return nil
+    }
+
+    var adminCount int
+    if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM accountsettings WHERE role = $1`, RoleAdmin).Scan(&adminCount); err != nil {
+        return fmt.Errorf("failed to check for existing admin: %w", err)
+    }
+    if adminCount > 0 {
+        return nil
+    }
+
+    hashedKey, err := bcrypt.GenerateFromPassword([]byte(apiKey), bcrypt.DefaultCost)
+    if err != nil {
+        return fmt.Errorf("failed to hash admin api key: %w", err)
+    }
+
+    _, err = pool.Exec(ctx, `
+        INSERT INTO accountsettings (username, email, password, email_verified, role)
+        VALUES ($1, $1, $2, TRUE, $3)
+        ON CONFLICT (username) DO NOTHING
+    `, username, string(hashedKey), RoleAdmin)
+    if err != nil {
+        return fmt.Errorf("failed to create bootstrap admin: %w", err)
+    }
+
+    return nil
+}
+
+// GetUserRole returns the role assigned to username.
+func (db *AccountDatabase) GetUserRole(username string) (string, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    var role string
+    err := db.Pool.QueryRow(ctx, `SELECT role FROM accountsettings WHERE username = $1`, username).Scan(&role)
+    if err != nil {
+        return "", fmt.Errorf("failed to get user role: %w", err)
+    }
+
+    return role, nil
+}
+
+// SetUserRole changes target's role. actor must currently hold the admin
+// role; the change is recorded in transaction_history for audit purposes.
+func (db *AccountDatabase) SetUserRole(actor, target, newRole string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    actorRole, err := db.GetUserRole(actor)
+    if err != nil {
+        return fmt.Errorf("failed to verify actor role: %w", err)
+    }
+    if actorRole != RoleAdmin {
+        return fmt.Errorf("actor %q is not authorized to change roles", actor)
+    }
+
+    tag, err := db.Pool.Exec(ctx, `UPDATE accountsettings SET role = $1 WHERE username = $2`, newRole, target)
+    if err != nil {
+        return fmt.Errorf("failed to set user role: %w", err)
+    }
+    if tag.RowsAffected() == 0 {
+        return fmt.Errorf("user %q not found", target)
+    }
+
+    _, err = db.Pool.Exec(ctx, `
+        INSERT INTO transaction_history (client_id, transaction_type, notes, status)
+        VALUES ($1, 'role_change', $2, 'Completed')
+    `, target, fmt.Sprintf("role changed to %q by %q", newRole, actor))
+    if err != nil {
+        return fmt.Errorf("failed to record role change: %w", err)
+    }
+
+    return nil
+}
+
+// HasPermission reports whether username's role grants perm.
+func (db *AccountDatabase) HasPermission(username, perm string) (bool, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    role, err := db.GetUserRole(username)
+    if err != nil {
+        return false, fmt.Errorf("failed to look up user role: %w", err)
+    }
+    if role == RoleAdmin {
+        return true, nil
+    }
+
+    var granted bool
+    err = db.Pool.QueryRow(ctx, `
+        SELECT EXISTS(SELECT 1 FROM permissions WHERE role = $1 AND permission = $2)
+    `, role, perm).Scan(&granted)
+    if err != nil {
+        return false, fmt.Errorf("failed to check permission: %w", err)
+    }
+
+    return granted, nil
+}
+
+// ListUsersByRole returns every user currently assigned role.
+func (db *AccountDatabase) ListUsersByRole(role string) ([]User, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    rows, err := db.Pool.Query(ctx, `
+        SELECT account_id, username, email, password, email_verified, role
+        FROM accountsettings
+        WHERE role = $1
+    `, role)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list users by role: %w", err)
+    }
+    defer rows.Close()
+
+    var users []User
+    for rows.Next() {
+        var user User
+        if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.EmailVerified, &user.Role); err != nil {
+            return nil, fmt.Errorf("failed to scan user: %w", err)
+        }
+        users = append(users, user)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("failed to iterate users: %w", err)
+    }
+
+    return users, nil
+}
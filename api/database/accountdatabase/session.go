@@ -0,0 +1,136 @@
+package accountdatabase
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// IssueSession issues a signed session token for username, to be called
+// after ValidateCredentials (and OTP verification, if enrolled) succeeds.
+// The token's jti is recorded in refresh_tokens so it can be revoked later.
+func (db *AccountDatabase) IssueSession(username string) (string, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    user, err := db.GetUserByUsername(username)
+    if err != nil {
+        return "", fmt.Errorf("failed to look up user for session issuance: %w", err)
+    }
+
+    jti, err := generateJTI()
+    if err != nil {
+        return "", fmt.Errorf("failed to generate session id: %w", err)
+    }
+
+    ttl := db.opts.SessionTTL
+    if ttl == 0 {
+        ttl = defaultSessionTTL
+    }
+    expiresAt := time.Now().Add(ttl)
+
+    _, err = db.Pool.Exec(ctx, `
+        INSERT INTO refresh_tokens (jti, account_id, expires_at, revoked)
+        VALUES ($1, $2, $3, FALSE)
+    `, jti, user.ID, expiresAt)
+    if err != nil {
+        return "", fmt.Errorf("failed to record session: %w", err)
+    }
+
+    token, err := db.sessions.Issue(user.ID, user.Username, jti, ttl)
+    if err != nil {
+        return "", fmt.Errorf("failed to issue session token: %w", err)
+    }
+
+    return token, nil
+}
+
+// AuthenticateSession validates a session token, checking that it has not
+// been revoked or expired, and returns the authenticated user.
+func (db *AccountDatabase) AuthenticateSession(token string) (*User, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    claims, err := db.sessions.Parse(token)
+    if err != nil {
+        return nil, fmt.Errorf("failed to parse session token: %w", err)
+    }
+
+    var revoked bool
+    var expiresAt time.Time
+    err = db.Pool.QueryRow(ctx, `
+        SELECT revoked, expires_at FROM refresh_tokens WHERE jti = $1
+    `, claims.ID).Scan(&revoked, &expiresAt)
+    if err != nil {
+        return nil, fmt.Errorf("failed to look up session: %w", err)
+    }
+    if revoked {
+        return nil, fmt.Errorf("session has been revoked")
+    }
+    if time.Now().After(expiresAt) {
+        return nil, fmt.Errorf("session has expired")
+    }
+
+    return db.GetUserByUsername(claims.Username)
+}
+
+// RevokeSession marks the session identified by jti as revoked so it can no
+// longer authenticate requests.
+func (db *AccountDatabase) RevokeSession(jti string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    _, err := db.Pool.Exec(ctx, `UPDATE refresh_tokens SET revoked = TRUE WHERE jti = $1`, jti)
+    if err != nil {
+        return fmt.Errorf("failed to revoke session: %w", err)
+    }
+
+    return nil
+}
+
+func generateJTI() (string, error) {
+    buf := make([]byte, 16)
+    if _, err := rand.Read(buf); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(buf), nil
+}
+
+type contextKey int
+
+const userContextKey contextKey = 0
+
+// Middleware extracts a bearer session token from the Authorization header
+// and authenticates it via AuthenticateSession — which, unlike a bare JWT
+// check, also rejects tokens revoked through RevokeSession — storing the
+// authenticated *User in the request context for downstream handlers.
+// Requests without a valid, unrevoked token are rejected with 401.
+func (db *AccountDatabase) Middleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        header := r.Header.Get("Authorization")
+        token, ok := strings.CutPrefix(header, "Bearer ")
+        if !ok || token == "" {
+            http.Error(w, "missing bearer token", http.StatusUnauthorized)
+            return
+        }
+
+        user, err := db.AuthenticateSession(token)
+        if err != nil {
+            http.Error(w, "invalid session token", http.StatusUnauthorized)
+            return
+        }
+
+        ctx := context.WithValue(r.Context(), userContextKey, user)
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
+
+// UserFromContext returns the user stored by Middleware, if any.
+func UserFromContext(ctx context.Context) (*User, bool) {
+    user, ok := ctx.Value(userContextKey).(*User)
+    return user, ok
+}
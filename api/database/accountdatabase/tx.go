@@ -0,0 +1,107 @@
+package accountdatabase
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strconv"
+
+    "github.com/jackc/pgx/v4"
+    "golang.org/x/crypto/bcrypt"
+
+    "github.com/Level-Up-Miami/level-up-miami/api/database/db"
+)
+
+// Tx wraps a pgx.Tx with a subset of AccountDatabase's methods (CreateUser,
+// GetUserByUsername, ValidateCredentials and AddTransaction) so callers can
+// compose those operations atomically within WithTx. The other
+// AccountDatabase methods have no Tx counterpart.
+type Tx struct {
+    pgxTx   pgx.Tx
+    queries *db.Queries
+}
+
+// CreateUser inserts a new user as part of tx.
+func (tx *Tx) CreateUser(ctx context.Context, username, password, email string) error {
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    if err != nil {
+        return fmt.Errorf("failed to hash password: %w", err)
+    }
+
+    if err := tx.queries.CreateUser(ctx, username, string(hashedPassword), email); err != nil {
+        return fmt.Errorf("failed to create user: %w", err)
+    }
+
+    return nil
+}
+
+// GetUserByUsername fetches a user by their username as part of tx.
+func (tx *Tx) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+    row, err := tx.queries.GetUserByUsername(ctx, username)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get user by username: %w", err)
+    }
+
+    return accountsettingToUser(row), nil
+}
+
+// ValidateCredentials checks a username/password pair as part of tx. The
+// third return value, otpRequired, mirrors AccountDatabase.ValidateCredentials.
+func (tx *Tx) ValidateCredentials(ctx context.Context, username, password string) (bool, bool, bool, error) {
+    creds, err := tx.queries.GetCredentialsByUsername(ctx, username)
+    if err != nil {
+        if errors.Is(err, pgx.ErrNoRows) {
+            return false, false, false, nil
+        }
+        return false, false, false, fmt.Errorf("failed to query accountsettings: %w", err)
+    }
+
+    if err := bcrypt.CompareHashAndPassword([]byte(creds.Password), []byte(password)); err != nil {
+        return false, creds.EmailVerified, false, nil
+    }
+
+    otpRequired, err := tx.queries.GetOTPConfirmedByUsername(ctx, username)
+    if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+        return false, false, false, fmt.Errorf("failed to query user_otp: %w", err)
+    }
+
+    return true, creds.EmailVerified, otpRequired, nil
+}
+
+// AddTransaction adds a new transaction to the transaction_history table on
+// behalf of the authenticated user, as part of tx. Taking *User (rather
+// than a raw client ID) ensures a caller can only record transactions for
+// the account it authenticated as, mirroring AccountDatabase.AddTransaction.
+func (tx *Tx) AddTransaction(ctx context.Context, user *User, transactionType, itemsSent, itemsReceived, notes string) error {
+    if err := tx.queries.AddTransaction(ctx, strconv.Itoa(user.ID), transactionType, itemsSent, itemsReceived, notes); err != nil {
+        return fmt.Errorf("failed to add transaction: %w", err)
+    }
+
+    return nil
+}
+
+// WithTx runs fn inside a single database transaction, committing if fn
+// returns nil and rolling back otherwise. This lets callers compose
+// multiple operations atomically, e.g. creating a user and issuing an
+// initial transaction in one commit.
+func (adb *AccountDatabase) WithTx(ctx context.Context, fn func(*Tx) error) error {
+    pgxTx, err := adb.Pool.Begin(ctx)
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+
+    tx := &Tx{pgxTx: pgxTx, queries: db.New(pgxTx)}
+
+    if err := fn(tx); err != nil {
+        if rbErr := pgxTx.Rollback(ctx); rbErr != nil {
+            return fmt.Errorf("failed to roll back transaction after error %q: %w", err, rbErr)
+        }
+        return err
+    }
+
+    if err := pgxTx.Commit(ctx); err != nil {
+        return fmt.Errorf("failed to commit transaction: %w", err)
+    }
+
+    return nil
+}
@@ -0,0 +1,251 @@
+// Package migrations applies the account database's schema as an ordered
+// set of versioned up/down SQL files, tracking progress in a
+// schema_migrations table so operators can evolve the schema safely.
+package migrations
+
+import (
+    "context"
+    "embed"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/jackc/pgx/v4/pgxpool"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// MigrationInfo describes one migration and whether it has been applied.
+type MigrationInfo struct {
+    Version int
+    Name    string
+    Applied bool
+}
+
+type migration struct {
+    version int
+    name    string
+    up      string
+    down    string
+}
+
+// loadMigrations reads the embedded *.sql files and pairs each version's
+// .up.sql and .down.sql into an ordered slice.
+func loadMigrations() ([]migration, error) {
+    entries, err := files.ReadDir(".")
+    if err != nil {
+        return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+    }
+
+    byVersion := make(map[int]*migration)
+    for _, entry := range entries {
+        name := entry.Name()
+        version, label, direction, err := parseMigrationFilename(name)
+        if err != nil {
+            return nil, err
+        }
+
+        contents, err := files.ReadFile(name)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read migration %q: %w", name, err)
+        }
+
+        m, ok := byVersion[version]
+        if !ok {
+            m = &migration{version: version, name: label}
+            byVersion[version] = m
+        }
+        switch direction {
+        case "up":
+            m.up = string(contents)
+        case "down":
+            m.down = string(contents)
+        }
+    }
+
+    migrations := make([]migration, 0, len(byVersion))
+    for _, m := range byVersion {
+        if m.up == "" {
+            return nil, fmt.Errorf("migration %04d is missing an .up.sql file", m.version)
+        }
+        migrations = append(migrations, *m)
+    }
+    sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+
+    return migrations, nil
+}
+
+// parseMigrationFilename splits "0001_init.up.sql" into version 1,
+// name "init", and direction "up".
+func parseMigrationFilename(filename string) (version int, name, direction string, err error) {
+    base := strings.TrimSuffix(filename, ".sql")
+    parts := strings.SplitN(base, ".", 2)
+    if len(parts) != 2 || (parts[1] != "up" && parts[1] != "down") {
+        return 0, "", "", fmt.Errorf("migration file %q must end in .up.sql or .down.sql", filename)
+    }
+    direction = parts[1]
+
+    versionAndName := strings.SplitN(parts[0], "_", 2)
+    if len(versionAndName) != 2 {
+        return 0, "", "", fmt.Errorf("migration file %q must be named <version>_<name>.{up,down}.sql", filename)
+    }
+
+    version, err = strconv.Atoi(versionAndName[0])
+    if err != nil {
+        return 0, "", "", fmt.Errorf("migration file %q has a non-numeric version: %w", filename, err)
+    }
+
+    return version, versionAndName[1], direction, nil
+}
+
+func ensureSchemaMigrationsTable(ctx context.Context, pool *pgxpool.Pool) error {
+    _, err := pool.Exec(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version INTEGER PRIMARY KEY,
+            name TEXT NOT NULL,
+            applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+        );
+    `)
+    if err != nil {
+        return fmt.Errorf("failed to create schema_migrations table: %w", err)
+    }
+
+    return nil
+}
+
+func appliedVersions(ctx context.Context, pool *pgxpool.Pool) (map[int]bool, error) {
+    rows, err := pool.Query(ctx, `SELECT version FROM schema_migrations`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+    }
+    defer rows.Close()
+
+    applied := make(map[int]bool)
+    for rows.Next() {
+        var version int
+        if err := rows.Scan(&version); err != nil {
+            return nil, fmt.Errorf("failed to scan schema_migrations row: %w", err)
+        }
+        applied[version] = true
+    }
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("failed to iterate schema_migrations: %w", err)
+    }
+
+    return applied, nil
+}
+
+// Migrate brings the schema up to the latest version. It is safe to call
+// on every startup.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+    return MigrateUp(ctx, pool)
+}
+
+// MigrateUp applies every migration that has not yet been recorded in
+// schema_migrations, in version order.
+func MigrateUp(ctx context.Context, pool *pgxpool.Pool) error {
+    if err := ensureSchemaMigrationsTable(ctx, pool); err != nil {
+        return err
+    }
+
+    all, err := loadMigrations()
+    if err != nil {
+        return err
+    }
+
+    applied, err := appliedVersions(ctx, pool)
+    if err != nil {
+        return err
+    }
+
+    for _, m := range all {
+        if applied[m.version] {
+            continue
+        }
+
+        if _, err := pool.Exec(ctx, m.up); err != nil {
+            return fmt.Errorf("failed to apply migration %04d_%s: %w", m.version, m.name, err)
+        }
+        if _, err := pool.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.version, m.name); err != nil {
+            return fmt.Errorf("failed to record migration %04d_%s: %w", m.version, m.name, err)
+        }
+    }
+
+    return nil
+}
+
+// MigrateDown rolls back the steps most recently applied migrations, in
+// reverse version order.
+func MigrateDown(ctx context.Context, pool *pgxpool.Pool, steps int) error {
+    if err := ensureSchemaMigrationsTable(ctx, pool); err != nil {
+        return err
+    }
+
+    all, err := loadMigrations()
+    if err != nil {
+        return err
+    }
+    byVersion := make(map[int]migration, len(all))
+    for _, m := range all {
+        byVersion[m.version] = m
+    }
+
+    applied, err := appliedVersions(ctx, pool)
+    if err != nil {
+        return err
+    }
+
+    appliedVersionsSorted := make([]int, 0, len(applied))
+    for version := range applied {
+        appliedVersionsSorted = append(appliedVersionsSorted, version)
+    }
+    sort.Sort(sort.Reverse(sort.IntSlice(appliedVersionsSorted)))
+
+    if steps > len(appliedVersionsSorted) {
+        steps = len(appliedVersionsSorted)
+    }
+
+    for i := 0; i < steps; i++ {
+        version := appliedVersionsSorted[i]
+        m, ok := byVersion[version]
+        if !ok || m.down == "" {
+            return fmt.Errorf("migration %04d has no .down.sql to roll back", version)
+        }
+
+        if _, err := pool.Exec(ctx, m.down); err != nil {
+            return fmt.Errorf("failed to roll back migration %04d_%s: %w", m.version, m.name, err)
+        }
+        if _, err := pool.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, version); err != nil {
+            return fmt.Errorf("failed to unrecord migration %04d_%s: %w", m.version, m.name, err)
+        }
+    }
+
+    return nil
+}
+
+// MigrateStatus reports every known migration and whether it has been
+// applied to pool.
+func MigrateStatus(ctx context.Context, pool *pgxpool.Pool) ([]MigrationInfo, error) {
+    if err := ensureSchemaMigrationsTable(ctx, pool); err != nil {
+        return nil, err
+    }
+
+    all, err := loadMigrations()
+    if err != nil {
+        return nil, err
+    }
+
+    applied, err := appliedVersions(ctx, pool)
+    if err != nil {
+        return nil, err
+    }
+
+    status := make([]MigrationInfo, 0, len(all))
+    for _, m := range all {
+        status = append(status, MigrationInfo{Version: m.version, Name: m.name, Applied: applied[m.version]})
+    }
+
+    return status, nil
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import "testing"
+
+func TestParseMigrationFilename(t *testing.T) {
+    version, name, direction, err := parseMigrationFilename("0003_add_roles.up.sql")
+    if err != nil {
+        t.Fatalf("parseMigrationFilename returned error: %v", err)
+    }
+    if version != 3 {
+        t.Errorf("version = %d, want 3", version)
+    }
+    if name != "add_roles" {
+        t.Errorf("name = %q, want %q", name, "add_roles")
+    }
+    if direction != "up" {
+        t.Errorf("direction = %q, want %q", direction, "up")
+    }
+}
+
+func TestParseMigrationFilenameRejectsBadNames(t *testing.T) {
+    cases := []string{
+        "add_roles.up.sql",     // missing version
+        "0003_add_roles.sql",   // missing up/down
+        "0003addroles.up.sql",  // missing version/name separator
+        "notanumber_foo.up.sql",
+    }
+    for _, name := range cases {
+        if _, _, _, err := parseMigrationFilename(name); err == nil {
+            t.Errorf("parseMigrationFilename(%q) = nil error, want error", name)
+        }
+    }
+}
+
+func TestLoadMigrationsOrdersByVersionAndPairsUpDown(t *testing.T) {
+    all, err := loadMigrations()
+    if err != nil {
+        t.Fatalf("loadMigrations returned error: %v", err)
+    }
+    if len(all) == 0 {
+        t.Fatal("loadMigrations returned no migrations")
+    }
+
+    for i, m := range all {
+        if m.up == "" {
+            t.Errorf("migration %04d_%s has no .up.sql contents", m.version, m.name)
+        }
+        if m.down == "" {
+            t.Errorf("migration %04d_%s has no .down.sql contents", m.version, m.name)
+        }
+        if i > 0 && all[i-1].version >= m.version {
+            t.Errorf("migrations not in ascending version order: %04d before %04d", all[i-1].version, m.version)
+        }
+    }
+}
@@ -0,0 +1,71 @@
+// Package session issues and validates the JWTs used to authenticate
+// requests once a user has passed ValidateCredentials (and, if enrolled,
+// OTP verification).
+package session
+
+import (
+    "fmt"
+    "time"
+
+    "github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are the custom JWT claims carried by a session token.
+type Claims struct {
+    AccountID int    `json:"account_id"`
+    Username  string `json:"username"`
+    jwt.RegisteredClaims
+}
+
+// Manager issues and validates session JWTs signed with a single shared
+// secret.
+type Manager struct {
+    secret []byte
+}
+
+// NewManager builds a Manager that signs and verifies tokens with secret.
+func NewManager(secret []byte) *Manager {
+    return &Manager{secret: secret}
+}
+
+// Issue creates a signed JWT for the given account, identified by jti so it
+// can be looked up and revoked later.
+func (m *Manager) Issue(accountID int, username, jti string, ttl time.Duration) (string, error) {
+    now := time.Now()
+    claims := Claims{
+        AccountID: accountID,
+        Username:  username,
+        RegisteredClaims: jwt.RegisteredClaims{
+            ID:        jti,
+            IssuedAt:  jwt.NewNumericDate(now),
+            ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+        },
+    }
+
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+    signed, err := token.SignedString(m.secret)
+    if err != nil {
+        return "", fmt.Errorf("failed to sign session token: %w", err)
+    }
+
+    return signed, nil
+}
+
+// Parse validates a signed JWT and returns its claims.
+func (m *Manager) Parse(tokenString string) (*Claims, error) {
+    var claims Claims
+    token, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (interface{}, error) {
+        if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+            return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+        }
+        return m.secret, nil
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to parse session token: %w", err)
+    }
+    if !token.Valid {
+        return nil, fmt.Errorf("session token is invalid")
+    }
+
+    return &claims, nil
+}